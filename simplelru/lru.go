@@ -4,29 +4,76 @@ import (
 	"container/list"
 	"errors"
 	"fmt"
+	"time"
 )
 
 // EvictCallback is used to get a callback when a cache entry is evicted
 type EvictCallback func(key interface{}, value interface{}, cost int64)
 
+// EvictReason distinguishes why an entry left the cache, passed to an
+// EvictCallbackV2.
+type EvictReason int
+
+const (
+	// EvictReasonCapacity means the entry was evicted to keep the cache's
+	// total cost under maxCost.
+	EvictReasonCapacity EvictReason = iota
+	// EvictReasonExpired means the entry was removed because its TTL
+	// elapsed, discovered lazily on a Get/Peek/Contains/UpdateCost or by
+	// DeleteExpired.
+	EvictReasonExpired
+	// EvictReasonRemoved means the entry was removed explicitly via Remove,
+	// RemoveOldest or Purge.
+	EvictReasonRemoved
+)
+
+// EvictCallbackV2 is used to get a callback when a cache entry is evicted,
+// along with the reason it was evicted. It is a superset of EvictCallback.
+// A cache built with NewLRU calls only its EvictCallback; one built with
+// NewLRUWithTTL calls only its EvictCallbackV2.
+type EvictCallbackV2 func(key interface{}, value interface{}, cost int64, reason EvictReason)
+
 // LRU implements a non-thread safe fixed size LRU cache
 type LRU struct {
-	maxCost       int64
-	evictList     *list.List
-	evictListCost int64
-	items         map[interface{}]*list.Element
-	onEvict       EvictCallback
+	maxCost          int64
+	defaultTTL       time.Duration
+	evictList        *list.List
+	evictListCost    int64
+	items            map[interface{}]*list.Element
+	onEvict          EvictCallback
+	onEvictV2        EvictCallbackV2
+	costReplaceOnAdd bool
+}
+
+// LRUOption configures an LRU at construction time.
+type LRUOption func(*LRU)
+
+// WithCostReplaceOnAdd makes Add replace an existing key's cost (as well
+// as its value) instead of leaving the original cost in place. Without
+// this option, Add's existing-key branch only updates the value, per its
+// historical "cost can't be updated" behavior; use UpdateCost to adjust
+// cost directly for a key that is already present.
+func WithCostReplaceOnAdd() LRUOption {
+	return func(c *LRU) {
+		c.costReplaceOnAdd = true
+	}
 }
 
 // entry is used to hold a value in the evictList
 type entry struct {
-	key   interface{}
-	value interface{}
-	cost  int64
+	key       interface{}
+	value     interface{}
+	cost      int64
+	expiresAt time.Time
+}
+
+// expired reports whether the entry's TTL has elapsed as of now.
+func (e *entry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
 }
 
 // NewLRU constructs an LRU of the given size
-func NewLRU(maxCost int64, onEvict EvictCallback) (*LRU, error) {
+func NewLRU(maxCost int64, onEvict EvictCallback, opts ...LRUOption) (*LRU, error) {
 	if maxCost <= 0 {
 		return nil, errors.New("must provide a positive size")
 	}
@@ -37,6 +84,32 @@ func NewLRU(maxCost int64, onEvict EvictCallback) (*LRU, error) {
 		items:         make(map[interface{}]*list.Element),
 		onEvict:       onEvict,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// NewLRUWithTTL constructs an LRU of the given size whose entries expire
+// defaultTTL after being added, unless overridden per-entry via
+// AddWithTTL. A zero defaultTTL means entries added via Add never expire.
+// onEvict is invoked for every removal, with a reason that distinguishes
+// TTL expiry from capacity eviction.
+func NewLRUWithTTL(maxCost int64, defaultTTL time.Duration, onEvict EvictCallbackV2, opts ...LRUOption) (*LRU, error) {
+	if maxCost <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+	c := &LRU{
+		maxCost:       maxCost,
+		defaultTTL:    defaultTTL,
+		evictListCost: 0,
+		evictList:     list.New(),
+		items:         make(map[interface{}]*list.Element),
+		onEvictV2:     onEvict,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
 	return c, nil
 }
 
@@ -44,7 +117,7 @@ func NewLRU(maxCost int64, onEvict EvictCallback) (*LRU, error) {
 func (c *LRU) Purge() {
 	for k, v := range c.items {
 		en := v.Value.(*entry)
-		c.callOnEvict(en)
+		c.callOnEvict(en, EvictReasonRemoved)
 		delete(c.items, k)
 	}
 	c.evictList.Init()
@@ -52,12 +125,36 @@ func (c *LRU) Purge() {
 }
 
 // Add adds a value to the cache.  Returns true if an eviction occurred.
+// If the cache was constructed with NewLRUWithTTL, the entry expires
+// after the configured defaultTTL; use AddWithTTL to override it.
 func (c *LRU) Add(key, value interface{}, cost int64) (evicted int) {
-	// Check for existing item - cost can't be updated
+	return c.addWithTTL(key, value, cost, c.defaultTTL)
+}
+
+// AddWithTTL adds a value to the cache with a per-entry TTL, overriding
+// the cache's defaultTTL. A zero ttl means the entry never expires.
+// Returns the number of entries evicted.
+func (c *LRU) AddWithTTL(key, value interface{}, cost int64, ttl time.Duration) (evicted int) {
+	return c.addWithTTL(key, value, cost, ttl)
+}
+
+func (c *LRU) addWithTTL(key, value interface{}, cost int64, ttl time.Duration) (evicted int) {
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+
+	// Check for existing item. Unless WithCostReplaceOnAdd was given, cost
+	// can't be updated this way - use UpdateCost instead.
 	if ent, ok := c.items[key]; ok {
 		c.evictList.MoveToFront(ent)
-		ent.Value.(*entry).value = value
-		return 0
+		en := ent.Value.(*entry)
+		en.value = value
+		en.expiresAt = expiresAt
+		if c.costReplaceOnAdd && cost != en.cost {
+			evicted = c.setCost(en, cost)
+		}
+		return evicted
 	}
 
 	if cost > c.maxCost {
@@ -65,10 +162,10 @@ func (c *LRU) Add(key, value interface{}, cost int64) (evicted int) {
 	}
 
 	// Add new item
-	ent := &entry{key, value, cost}
-	entry := c.evictList.PushFront(ent)
+	ent := &entry{key, value, cost, expiresAt}
+	element := c.evictList.PushFront(ent)
 	c.evictListCost += cost
-	c.items[key] = entry
+	c.items[key] = element
 
 	// Verify size not exceeded
 	for c.evictListCost > c.maxCost {
@@ -78,40 +175,84 @@ func (c *LRU) Add(key, value interface{}, cost int64) (evicted int) {
 	return evicted
 }
 
-// Get looks up a key's value from the cache.
+// Get looks up a key's value from the cache. An expired entry is treated
+// as absent and lazily removed.
 func (c *LRU) Get(key interface{}) (value interface{}, ok bool) {
 	if ent, ok := c.items[key]; ok {
-		c.evictList.MoveToFront(ent)
-		if ent.Value.(*entry) == nil {
+		en := ent.Value.(*entry)
+		if en.expired(time.Now()) {
+			c.removeElement(ent, EvictReasonExpired)
 			return nil, false
 		}
-		return ent.Value.(*entry).value, true
+		c.evictList.MoveToFront(ent)
+		return en.value, true
 	}
 	return
 }
 
-// Contains checks if a key is in the cache, without updating the recent-ness
-// or deleting it for being stale.
+// Contains checks if a key is in the cache, without updating the
+// recent-ness. An expired entry is treated as absent and lazily removed.
 func (c *LRU) Contains(key interface{}) (ok bool) {
-	_, ok = c.items[key]
-	return ok
+	ent, ok := c.items[key]
+	if !ok {
+		return false
+	}
+	if ent.Value.(*entry).expired(time.Now()) {
+		c.removeElement(ent, EvictReasonExpired)
+		return false
+	}
+	return true
 }
 
 // Peek returns the key value (or undefined if not found) without updating
-// the "recently used"-ness of the key.
+// the "recently used"-ness of the key. An expired entry is treated as
+// absent and lazily removed.
 func (c *LRU) Peek(key interface{}) (value interface{}, ok bool) {
 	var ent *list.Element
 	if ent, ok = c.items[key]; ok {
-		return ent.Value.(*entry).value, true
+		en := ent.Value.(*entry)
+		if en.expired(time.Now()) {
+			c.removeElement(ent, EvictReasonExpired)
+			return nil, false
+		}
+		return en.value, true
 	}
 	return nil, ok
 }
 
+// ExpiresAt returns the time at which key's entry expires, and whether key
+// is present and carries an expiry at all (the zero time and false are
+// returned for entries with no TTL).
+func (c *LRU) ExpiresAt(key interface{}) (expiresAt time.Time, ok bool) {
+	ent, ok := c.items[key]
+	if !ok {
+		return time.Time{}, false
+	}
+	en := ent.Value.(*entry)
+	return en.expiresAt, !en.expiresAt.IsZero()
+}
+
+// DeleteExpired sweeps the cache and removes all entries whose TTL has
+// elapsed, invoking onEvict with EvictReasonExpired for each. Returns the
+// number of entries removed.
+func (c *LRU) DeleteExpired() (removed int) {
+	now := time.Now()
+	for ent := c.evictList.Back(); ent != nil; {
+		prev := ent.Prev()
+		if ent.Value.(*entry).expired(now) {
+			c.removeElement(ent, EvictReasonExpired)
+			removed++
+		}
+		ent = prev
+	}
+	return removed
+}
+
 // Remove removes the provided key from the cache, returning if the
 // key was contained.
 func (c *LRU) Remove(key interface{}) (present bool) {
 	if ent, ok := c.items[key]; ok {
-		c.removeElement(ent)
+		c.removeElement(ent, EvictReasonRemoved)
 		return true
 	}
 	return false
@@ -121,7 +262,7 @@ func (c *LRU) Remove(key interface{}) (present bool) {
 func (c *LRU) RemoveOldest() (key, value interface{}, ok bool) {
 	ent := c.evictList.Back()
 	if ent != nil {
-		c.removeElement(ent)
+		c.removeElement(ent, EvictReasonRemoved)
 		kv := ent.Value.(*entry)
 		return kv.key, kv.value, true
 	}
@@ -149,6 +290,71 @@ func (c *LRU) Keys() []interface{} {
 	return keys
 }
 
+// Range walks the cache from oldest to newest, calling fn with each key,
+// value and cost in turn, and stops early if fn returns false. Unlike
+// Keys, Range does not allocate, so it is suited to streaming large
+// caches for snapshotting, metrics export or persistence. Mutating the
+// cache during iteration invalidates the walk, matching container/list.
+func (c *LRU) Range(fn func(key, value interface{}, cost int64) bool) {
+	for ent := c.evictList.Back(); ent != nil; ent = ent.Prev() {
+		en := ent.Value.(*entry)
+		if !fn(en.key, en.value, en.cost) {
+			return
+		}
+	}
+}
+
+// RangeNewest walks the cache from newest to oldest, calling fn with each
+// key, value and cost in turn, and stops early if fn returns false.
+func (c *LRU) RangeNewest(fn func(key, value interface{}, cost int64) bool) {
+	for ent := c.evictList.Front(); ent != nil; ent = ent.Next() {
+		en := ent.Value.(*entry)
+		if !fn(en.key, en.value, en.cost) {
+			return
+		}
+	}
+}
+
+// Iter returns a cursor positioned before the oldest entry. Call Next to
+// advance it and Key/Value/Cost to read the current entry. Mutating the
+// cache during iteration invalidates the cursor, matching container/list.
+func (c *LRU) Iter() *Iterator {
+	return &Iterator{list: c.evictList}
+}
+
+// Iterator is a lightweight, non-allocating cursor over an LRU's entries,
+// oldest to newest.
+type Iterator struct {
+	list *list.List
+	cur  *list.Element
+}
+
+// Next advances the cursor to the next entry and reports whether one was
+// found.
+func (it *Iterator) Next() bool {
+	if it.cur == nil {
+		it.cur = it.list.Back()
+	} else {
+		it.cur = it.cur.Prev()
+	}
+	return it.cur != nil
+}
+
+// Key returns the key at the cursor's current position.
+func (it *Iterator) Key() interface{} {
+	return it.cur.Value.(*entry).key
+}
+
+// Value returns the value at the cursor's current position.
+func (it *Iterator) Value() interface{} {
+	return it.cur.Value.(*entry).value
+}
+
+// Cost returns the cost at the cursor's current position.
+func (it *Iterator) Cost() int64 {
+	return it.cur.Value.(*entry).cost
+}
+
 // Len returns the number of items in the cache.
 func (c *LRU) Len() int {
 	return c.evictList.Len()
@@ -159,6 +365,43 @@ func (c *LRU) Cost() int64 {
 	return c.evictListCost
 }
 
+// UpdateCost adjusts the cost of an already-present key in place,
+// promoting it to the front and driving the eviction loop if the new
+// total exceeds maxCost. Returns ok=false if key is not present or its
+// TTL has expired (in which case it is lazily removed, like Get). Panics
+// if newCost is bigger than maxCost, matching Add.
+func (c *LRU) UpdateCost(key interface{}, newCost int64) (evicted int, ok bool) {
+	ent, ok := c.items[key]
+	if !ok {
+		return 0, false
+	}
+	en := ent.Value.(*entry)
+	if en.expired(time.Now()) {
+		c.removeElement(ent, EvictReasonExpired)
+		return 0, false
+	}
+
+	c.evictList.MoveToFront(ent)
+	return c.setCost(en, newCost), true
+}
+
+// setCost applies newCost to en, adjusting evictListCost and driving the
+// eviction loop if the new total exceeds maxCost. Panics if newCost is
+// bigger than maxCost.
+func (c *LRU) setCost(en *entry, newCost int64) (evicted int) {
+	if newCost > c.maxCost {
+		panic(fmt.Errorf("cost %d is bigger than max cost %d", newCost, c.maxCost))
+	}
+	c.evictListCost += newCost - en.cost
+	en.cost = newCost
+
+	for c.evictListCost > c.maxCost {
+		evicted++
+		c.removeOldest()
+	}
+	return evicted
+}
+
 // Resize changes the cache size.
 func (c *LRU) Resize(maxCost int64) (evicted int) {
 	if maxCost <= 0 {
@@ -176,24 +419,25 @@ func (c *LRU) Resize(maxCost int64) (evicted int) {
 func (c *LRU) removeOldest() {
 	ent := c.evictList.Back()
 	if ent != nil {
-		c.removeElement(ent)
+		c.removeElement(ent, EvictReasonCapacity)
 	}
 }
 
 // removeElement is used to remove a given list element from the cache
-func (c *LRU) removeElement(e *list.Element) {
+func (c *LRU) removeElement(e *list.Element, reason EvictReason) {
 	c.evictList.Remove(e)
 	kv := e.Value.(*entry)
 	delete(c.items, kv.key)
 	c.evictListCost -= kv.cost
-	c.callOnEvict(kv)
+	c.callOnEvict(kv, reason)
 }
 
-// callOnEvict calls onEvict and blocks if needed
-func (c *LRU) callOnEvict(e *entry) {
-	if c.onEvict == nil {
-		return
+// callOnEvict calls onEvict and onEvictV2, if set, and blocks if needed
+func (c *LRU) callOnEvict(e *entry, reason EvictReason) {
+	if c.onEvict != nil {
+		c.onEvict(e.key, e.value, e.cost)
+	}
+	if c.onEvictV2 != nil {
+		c.onEvictV2(e.key, e.value, e.cost, reason)
 	}
-
-	c.onEvict(e.key, e.value, e.cost)
 }
@@ -0,0 +1,232 @@
+package simplelru
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+)
+
+// sieveEntry is used to hold a value in the evictList of a SIEVE cache.
+type sieveEntry struct {
+	key     interface{}
+	value   interface{}
+	cost    int64
+	visited bool
+}
+
+// SIEVE implements a non-thread safe fixed size cache using the SIEVE
+// eviction algorithm (https://cachemon.github.io/SIEVE-website/). SIEVE
+// keeps a single FIFO-ish list and a "hand" pointer that walks it backwards
+// looking for an entry to evict, giving entries that have been accessed
+// again since insertion a second chance. It is a drop-in alternative to
+// LRU for scan-heavy workloads, where SIEVE tends to retain a higher hit
+// ratio.
+type SIEVE struct {
+	maxCost       int64
+	evictList     *list.List
+	evictListCost int64
+	items         map[interface{}]*list.Element
+	hand          *list.Element
+	onEvict       EvictCallback
+}
+
+// NewSIEVE constructs a SIEVE of the given size.
+func NewSIEVE(maxCost int64, onEvict EvictCallback) (*SIEVE, error) {
+	if maxCost <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+	c := &SIEVE{
+		maxCost:   maxCost,
+		evictList: list.New(),
+		items:     make(map[interface{}]*list.Element),
+		onEvict:   onEvict,
+	}
+	return c, nil
+}
+
+// Purge is used to completely clear the cache.
+func (c *SIEVE) Purge() {
+	for k, v := range c.items {
+		en := v.Value.(*sieveEntry)
+		c.callOnEvict(en)
+		delete(c.items, k)
+	}
+	c.evictList.Init()
+	c.evictListCost = 0
+	c.hand = nil
+}
+
+// Add adds a value to the cache. Returns the number of entries evicted.
+func (c *SIEVE) Add(key, value interface{}, cost int64) (evicted int) {
+	// Check for existing item - cost can't be updated
+	if ent, ok := c.items[key]; ok {
+		en := ent.Value.(*sieveEntry)
+		en.value = value
+		en.visited = true
+		return 0
+	}
+
+	if cost > c.maxCost {
+		panic(fmt.Errorf("cost %d is bigger than max cost %d", cost, c.maxCost))
+	}
+
+	// Add new item at the head, unvisited
+	en := &sieveEntry{key: key, value: value, cost: cost}
+	element := c.evictList.PushFront(en)
+	c.evictListCost += cost
+	c.items[key] = element
+
+	// Verify size not exceeded
+	for c.evictListCost > c.maxCost {
+		evicted++
+		c.evict()
+	}
+	return evicted
+}
+
+// Get looks up a key's value from the cache, marking it as visited so it
+// is given a second chance on the next eviction sweep.
+func (c *SIEVE) Get(key interface{}) (value interface{}, ok bool) {
+	if ent, ok := c.items[key]; ok {
+		en := ent.Value.(*sieveEntry)
+		en.visited = true
+		return en.value, true
+	}
+	return nil, false
+}
+
+// Contains checks if a key is in the cache, without updating the
+// visited bit or deleting it for being stale.
+func (c *SIEVE) Contains(key interface{}) (ok bool) {
+	_, ok = c.items[key]
+	return ok
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// the visited bit.
+func (c *SIEVE) Peek(key interface{}) (value interface{}, ok bool) {
+	var ent *list.Element
+	if ent, ok = c.items[key]; ok {
+		return ent.Value.(*sieveEntry).value, true
+	}
+	return nil, ok
+}
+
+// Remove removes the provided key from the cache, returning if the
+// key was contained.
+func (c *SIEVE) Remove(key interface{}) (present bool) {
+	if ent, ok := c.items[key]; ok {
+		c.removeElement(ent)
+		return true
+	}
+	return false
+}
+
+// RemoveOldest removes the item at the back of the list from the cache.
+func (c *SIEVE) RemoveOldest() (key, value interface{}, ok bool) {
+	ent := c.evictList.Back()
+	if ent != nil {
+		c.removeElement(ent)
+		kv := ent.Value.(*sieveEntry)
+		return kv.key, kv.value, true
+	}
+	return nil, nil, false
+}
+
+// GetOldest returns the entry at the back of the list.
+func (c *SIEVE) GetOldest() (key, value interface{}, ok bool) {
+	ent := c.evictList.Back()
+	if ent != nil {
+		kv := ent.Value.(*sieveEntry)
+		return kv.key, kv.value, true
+	}
+	return nil, nil, false
+}
+
+// Keys returns a slice of the keys in the cache, from oldest to newest
+// insertion order.
+func (c *SIEVE) Keys() []interface{} {
+	keys := make([]interface{}, len(c.items))
+	i := 0
+	for ent := c.evictList.Back(); ent != nil; ent = ent.Prev() {
+		keys[i] = ent.Value.(*sieveEntry).key
+		i++
+	}
+	return keys
+}
+
+// Len returns the number of items in the cache.
+func (c *SIEVE) Len() int {
+	return c.evictList.Len()
+}
+
+// Cost returns the total cost of items in the cache.
+func (c *SIEVE) Cost() int64 {
+	return c.evictListCost
+}
+
+// Resize changes the cache size.
+func (c *SIEVE) Resize(maxCost int64) (evicted int) {
+	if maxCost <= 0 {
+		panic(errors.New("must provide a positive size"))
+	}
+	c.maxCost = maxCost
+	for c.evictListCost > c.maxCost {
+		evicted++
+		c.evict()
+	}
+	return evicted
+}
+
+// evict runs one step of the SIEVE algorithm: the hand walks backwards
+// from its current position (starting at the tail on the first eviction),
+// clearing the visited bit and advancing over visited entries, and
+// evicting the first unvisited entry it finds.
+func (c *SIEVE) evict() {
+	hand := c.hand
+	if hand == nil {
+		hand = c.evictList.Back()
+	}
+
+	for hand != nil {
+		en := hand.Value.(*sieveEntry)
+		if en.visited {
+			en.visited = false
+			hand = hand.Prev()
+			if hand == nil {
+				hand = c.evictList.Back()
+			}
+			continue
+		}
+		break
+	}
+
+	if hand == nil {
+		return
+	}
+
+	c.hand = hand.Prev()
+	c.removeElement(hand)
+}
+
+// removeElement is used to remove a given list element from the cache,
+// repairing the hand if it currently points at the removed element.
+func (c *SIEVE) removeElement(e *list.Element) {
+	if c.hand == e {
+		c.hand = e.Prev()
+	}
+	c.evictList.Remove(e)
+	kv := e.Value.(*sieveEntry)
+	delete(c.items, kv.key)
+	c.evictListCost -= kv.cost
+	c.callOnEvict(kv)
+}
+
+// callOnEvict calls onEvict and blocks if needed.
+func (c *SIEVE) callOnEvict(e *sieveEntry) {
+	if c.onEvict == nil {
+		return
+	}
+
+	c.onEvict(e.key, e.value, e.cost)
+}
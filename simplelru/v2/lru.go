@@ -0,0 +1,200 @@
+// Package v2 is a generic, type-parameterized mirror of simplelru. It
+// trades the interface{} map and boxed entry values of simplelru.LRU for
+// typed equivalents, removing the boxing of keys and values on Add.
+// Entries are still held in a container/list, so Get, Peek and
+// removeElement still assert each element's value to *entry[K, V].
+package v2
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+)
+
+// EvictCallback is used to get a callback when a cache entry is evicted
+type EvictCallback[K comparable, V any] func(key K, value V, cost int64)
+
+// LRU implements a non-thread safe fixed size LRU cache
+type LRU[K comparable, V any] struct {
+	maxCost       int64
+	evictList     *list.List
+	evictListCost int64
+	items         map[K]*list.Element
+	onEvict       EvictCallback[K, V]
+}
+
+// entry is used to hold a value in the evictList
+type entry[K comparable, V any] struct {
+	key   K
+	value V
+	cost  int64
+}
+
+// NewLRU constructs an LRU of the given size
+func NewLRU[K comparable, V any](maxCost int64, onEvict EvictCallback[K, V]) (*LRU[K, V], error) {
+	if maxCost <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+	c := &LRU[K, V]{
+		maxCost:       maxCost,
+		evictListCost: 0,
+		evictList:     list.New(),
+		items:         make(map[K]*list.Element),
+		onEvict:       onEvict,
+	}
+	return c, nil
+}
+
+// Purge is used to completely clear the cache.
+func (c *LRU[K, V]) Purge() {
+	for k, v := range c.items {
+		en := v.Value.(*entry[K, V])
+		c.callOnEvict(en)
+		delete(c.items, k)
+	}
+	c.evictList.Init()
+	c.evictListCost = 0
+}
+
+// Add adds a value to the cache.  Returns the number of entries evicted.
+func (c *LRU[K, V]) Add(key K, value V, cost int64) (evicted int) {
+	// Check for existing item - cost can't be updated
+	if ent, ok := c.items[key]; ok {
+		c.evictList.MoveToFront(ent)
+		ent.Value.(*entry[K, V]).value = value
+		return 0
+	}
+
+	if cost > c.maxCost {
+		panic(fmt.Errorf("cost %d is bigger than max cost %d", cost, c.maxCost))
+	}
+
+	// Add new item
+	ent := &entry[K, V]{key, value, cost}
+	element := c.evictList.PushFront(ent)
+	c.evictListCost += cost
+	c.items[key] = element
+
+	// Verify size not exceeded
+	for c.evictListCost > c.maxCost {
+		evicted++
+		c.removeOldest()
+	}
+	return evicted
+}
+
+// Get looks up a key's value from the cache.
+func (c *LRU[K, V]) Get(key K) (value V, ok bool) {
+	if ent, ok := c.items[key]; ok {
+		c.evictList.MoveToFront(ent)
+		return ent.Value.(*entry[K, V]).value, true
+	}
+	return value, false
+}
+
+// Contains checks if a key is in the cache, without updating the recent-ness
+// or deleting it for being stale.
+func (c *LRU[K, V]) Contains(key K) (ok bool) {
+	_, ok = c.items[key]
+	return ok
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// the "recently used"-ness of the key.
+func (c *LRU[K, V]) Peek(key K) (value V, ok bool) {
+	if ent, ok := c.items[key]; ok {
+		return ent.Value.(*entry[K, V]).value, true
+	}
+	return value, false
+}
+
+// Remove removes the provided key from the cache, returning if the
+// key was contained.
+func (c *LRU[K, V]) Remove(key K) (present bool) {
+	if ent, ok := c.items[key]; ok {
+		c.removeElement(ent)
+		return true
+	}
+	return false
+}
+
+// RemoveOldest removes the oldest item from the cache.
+func (c *LRU[K, V]) RemoveOldest() (key K, value V, ok bool) {
+	ent := c.evictList.Back()
+	if ent != nil {
+		c.removeElement(ent)
+		kv := ent.Value.(*entry[K, V])
+		return kv.key, kv.value, true
+	}
+	return key, value, false
+}
+
+// GetOldest returns the oldest entry
+func (c *LRU[K, V]) GetOldest() (key K, value V, ok bool) {
+	ent := c.evictList.Back()
+	if ent != nil {
+		kv := ent.Value.(*entry[K, V])
+		return kv.key, kv.value, true
+	}
+	return key, value, false
+}
+
+// Keys returns a slice of the keys in the cache, from oldest to newest.
+func (c *LRU[K, V]) Keys() []K {
+	keys := make([]K, len(c.items))
+	i := 0
+	for ent := c.evictList.Back(); ent != nil; ent = ent.Prev() {
+		keys[i] = ent.Value.(*entry[K, V]).key
+		i++
+	}
+	return keys
+}
+
+// Len returns the number of items in the cache.
+func (c *LRU[K, V]) Len() int {
+	return c.evictList.Len()
+}
+
+// Cost returns the total cost of items in the cache
+func (c *LRU[K, V]) Cost() int64 {
+	return c.evictListCost
+}
+
+// Resize changes the cache size.
+func (c *LRU[K, V]) Resize(maxCost int64) (evicted int) {
+	if maxCost <= 0 {
+		panic(errors.New("must provide a positive size"))
+	}
+	c.maxCost = maxCost
+	for c.evictListCost > c.maxCost {
+		evicted++
+		c.removeOldest()
+	}
+	return evicted
+}
+
+// removeOldest removes the oldest item from the cache.
+func (c *LRU[K, V]) removeOldest() {
+	ent := c.evictList.Back()
+	if ent != nil {
+		c.removeElement(ent)
+	}
+}
+
+// removeElement is used to remove a given list element from the cache
+func (c *LRU[K, V]) removeElement(e *list.Element) {
+	c.evictList.Remove(e)
+	kv := e.Value.(*entry[K, V])
+	delete(c.items, kv.key)
+	c.evictListCost -= kv.cost
+	c.callOnEvict(kv)
+}
+
+// callOnEvict calls onEvict and blocks if needed
+func (c *LRU[K, V]) callOnEvict(e *entry[K, V]) {
+	if c.onEvict == nil {
+		return
+	}
+
+	c.onEvict(e.key, e.value, e.cost)
+}